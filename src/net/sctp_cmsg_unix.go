@@ -0,0 +1,121 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build freebsd linux netbsd openbsd
+
+package net
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// sysSndInfo mirrors struct sctp_sndinfo, the ancillary data attached
+// to an outgoing SOCK_SEQPACKET message via the SCTP_SNDINFO cmsg type.
+type sysSndInfo struct {
+	Stream  uint16
+	Flags   uint16
+	PPID    uint32
+	Context uint32
+	AssocID int32
+}
+
+// sysSndRcvInfo mirrors struct sctp_sndrcvinfo, the ancillary data the
+// kernel attaches to a received message via the (older, still widely
+// supported) SCTP_SNDRCV cmsg type.
+type sysSndRcvInfo struct {
+	Stream     uint16
+	SSN        uint16
+	Flags      uint16
+	_          uint16
+	PPID       uint32
+	Context    uint32
+	Timetolive uint32
+	TSN        uint32
+	CumTSN     uint32
+	AssocID    int32
+}
+
+// sysRcvInfo mirrors struct sctp_rcvinfo, the ancillary data attached
+// via the newer SCTP_RCVINFO cmsg type.
+type sysRcvInfo struct {
+	Stream  uint16
+	SSN     uint16
+	Flags   uint16
+	_       uint16
+	PPID    uint32
+	TSN     uint32
+	CumTSN  uint32
+	Context uint32
+	AssocID int32
+}
+
+// sctpCmsgSpace is large enough to hold whichever of the SCTP_SNDRCV or
+// SCTP_RCVINFO ancillary messages the kernel decides to deliver.
+var sctpCmsgSpace = syscall.CmsgSpace(int(unsafe.Sizeof(sysSndRcvInfo{})))
+
+// marshalSndInfo encodes info as a single SCTP_SNDINFO cmsg, ready to
+// pass as the oob argument to sendmsg.
+func marshalSndInfo(info SndInfo) []byte {
+	space := syscall.CmsgSpace(int(unsafe.Sizeof(sysSndInfo{})))
+	buf := make([]byte, space)
+	h := (*syscall.Cmsghdr)(unsafe.Pointer(&buf[0]))
+	h.Level = syscall.IPPROTO_SCTP
+	h.Type = syscall.SCTP_SNDINFO
+	h.SetLen(syscall.CmsgLen(int(unsafe.Sizeof(sysSndInfo{}))))
+	si := (*sysSndInfo)(unsafe.Pointer(&buf[syscall.CmsgLen(0)]))
+	si.Stream = info.Stream
+	si.Flags = info.Flags
+	si.PPID = info.PPID
+	si.Context = info.Context
+	si.AssocID = info.AssocID
+	return buf
+}
+
+// parseSndRcvInfo scans the ancillary data returned by recvmsg for an
+// SCTP_SNDRCV or SCTP_RCVINFO message and decodes it into a SndRcvInfo.
+// If neither is present, it returns the zero value: ordinary data
+// without a notification still reads correctly, just without metadata.
+func parseSndRcvInfo(oob []byte) (SndRcvInfo, error) {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return SndRcvInfo{}, err
+	}
+	for _, m := range msgs {
+		if m.Header.Level != syscall.IPPROTO_SCTP {
+			continue
+		}
+		switch m.Header.Type {
+		case syscall.SCTP_SNDRCV:
+			if len(m.Data) < int(unsafe.Sizeof(sysSndRcvInfo{})) {
+				continue
+			}
+			raw := *(*sysSndRcvInfo)(unsafe.Pointer(&m.Data[0]))
+			return SndRcvInfo{
+				Stream:  raw.Stream,
+				SSN:     raw.SSN,
+				Flags:   raw.Flags,
+				PPID:    raw.PPID,
+				Context: raw.Context,
+				TSN:     raw.TSN,
+				AssocID: raw.AssocID,
+			}, nil
+		case syscall.SCTP_RCVINFO:
+			if len(m.Data) < int(unsafe.Sizeof(sysRcvInfo{})) {
+				continue
+			}
+			raw := *(*sysRcvInfo)(unsafe.Pointer(&m.Data[0]))
+			return SndRcvInfo{
+				Stream:  raw.Stream,
+				SSN:     raw.SSN,
+				Flags:   raw.Flags,
+				PPID:    raw.PPID,
+				Context: raw.Context,
+				TSN:     raw.TSN,
+				AssocID: raw.AssocID,
+			}, nil
+		}
+	}
+	return SndRcvInfo{}, nil
+}
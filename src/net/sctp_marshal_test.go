@@ -0,0 +1,249 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build freebsd linux netbsd openbsd
+
+package net
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+func TestIPFromSockaddrStorage(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   IP
+	}{
+		{"ipv4", IPv4(192, 0, 2, 1)},
+		{"ipv6", ParseIP("2001:db8::1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			family := (&SCTPAddr{IPs: []IP{tt.ip}}).family()
+			sa, err := ipToSockaddr(family, tt.ip, 7777, "")
+			if err != nil {
+				t.Fatalf("ipToSockaddr: %v", err)
+			}
+			b, err := marshalSCTPAddr(sa)
+			if err != nil {
+				t.Fatalf("marshalSCTPAddr: %v", err)
+			}
+			buf := make([]byte, sockaddrStorageSize)
+			copy(buf, b)
+			got := ipFromSockaddrStorage(buf)
+			if !got.Equal(tt.ip) {
+				t.Errorf("ipFromSockaddrStorage(%v) = %v, want %v", buf, got, tt.ip)
+			}
+		})
+	}
+}
+
+// TestMarshalPeerAddrParams checks that marshalPeerAddrParams lays its
+// fields out at the fixed offsets struct sctp_paddrparams uses on the
+// wire, including the packed uint16/uint32 boundary at PathMaxRxt.
+func TestMarshalPeerAddrParams(t *testing.T) {
+	addr := make([]byte, sockaddrStorageSize)
+	addr[0] = syscall.AF_INET
+	addr[4] = 192
+	addr[5] = 0
+	addr[6] = 2
+	addr[7] = 1
+
+	b := marshalPeerAddrParams(42, addr, 3000, 5, syscall.SPP_HB_ENABLE)
+	if len(b) != sizeofSysPeerAddrParams {
+		t.Fatalf("len(b) = %d, want %d", len(b), sizeofSysPeerAddrParams)
+	}
+	if got := nativeEndian.Uint32(b[peerAddrParamsAssocIDOff:]); got != 42 {
+		t.Errorf("AssocID = %d, want 42", got)
+	}
+	if !bytes.Equal(b[peerAddrParamsAddressOff:peerAddrParamsAddressOff+sockaddrStorageSize], addr) {
+		t.Errorf("Address mismatch")
+	}
+	if got := nativeEndian.Uint32(b[peerAddrParamsHBIntervalOff:]); got != 3000 {
+		t.Errorf("HBInterval = %d, want 3000", got)
+	}
+	if got := nativeEndian.Uint16(b[peerAddrParamsPathMaxRxtOff:]); got != 5 {
+		t.Errorf("PathMaxRxt = %d, want 5", got)
+	}
+	if got := nativeEndian.Uint32(b[peerAddrParamsFlagsOff:]); got != syscall.SPP_HB_ENABLE {
+		t.Errorf("Flags = %d, want %d", got, syscall.SPP_HB_ENABLE)
+	}
+}
+
+// TestParseSCTPGetAddrs checks that parseSCTPGetAddrs reads the
+// addrs[] entries of struct sctp_getaddrs back out of a buffer laid
+// out the way the kernel fills it: entries packed back-to-back after
+// the header, each sockaddr_storage-sized.
+func TestParseSCTPGetAddrs(t *testing.T) {
+	ips := []IP{IPv4(10, 0, 0, 1), IPv4(10, 0, 0, 2)}
+	raw := make([]byte, len(ips)*sockaddrStorageSize)
+	for i, ip := range ips {
+		family := (&SCTPAddr{IPs: []IP{ip}}).family()
+		sa, err := ipToSockaddr(family, ip, 0, "")
+		if err != nil {
+			t.Fatalf("ipToSockaddr: %v", err)
+		}
+		b, err := marshalSCTPAddr(sa)
+		if err != nil {
+			t.Fatalf("marshalSCTPAddr: %v", err)
+		}
+		copy(raw[i*sockaddrStorageSize:], b)
+	}
+	got := parseSCTPGetAddrs(raw, len(ips))
+	if len(got) != len(ips) {
+		t.Fatalf("len(got) = %d, want %d", len(got), len(ips))
+	}
+	for i, ip := range ips {
+		if !got[i].Equal(ip) {
+			t.Errorf("got[%d] = %v, want %v", i, got[i], ip)
+		}
+	}
+}
+
+// TestParseSCTPNotificationPeerAddrChange checks that a
+// SCTP_PEER_ADDR_CHANGE notification is decoded with State, Error and
+// AssocID read from their correct offsets now that Addr is sized as a
+// sockaddr_storage instead of a sockaddr_in6.
+func TestParseSCTPNotificationPeerAddrChange(t *testing.T) {
+	ip := IPv4(198, 51, 100, 7)
+	family := (&SCTPAddr{IPs: []IP{ip}}).family()
+	sa, err := ipToSockaddr(family, ip, 0, "")
+	if err != nil {
+		t.Fatalf("ipToSockaddr: %v", err)
+	}
+	addrBytes, err := marshalSCTPAddr(sa)
+	if err != nil {
+		t.Fatalf("marshalSCTPAddr: %v", err)
+	}
+
+	var raw sysPeerAddrChange
+	raw.Header.Type = syscall.SCTP_PEER_ADDR_CHANGE
+	copy(raw.Addr[:], addrBytes)
+	raw.State = 1
+	raw.Error = 0
+	raw.AssocID = 99
+	b := (*[unsafe.Sizeof(raw)]byte)(unsafe.Pointer(&raw))[:]
+
+	note, err := parseSCTPNotification(b)
+	if err != nil {
+		t.Fatalf("parseSCTPNotification: %v", err)
+	}
+	pac, ok := note.(PeerAddrChange)
+	if !ok {
+		t.Fatalf("note = %T, want PeerAddrChange", note)
+	}
+	if !pac.Addr.Equal(ip) {
+		t.Errorf("Addr = %v, want %v", pac.Addr, ip)
+	}
+	if pac.State != 1 {
+		t.Errorf("State = %d, want 1", pac.State)
+	}
+	if pac.AssocID != 99 {
+		t.Errorf("AssocID = %d, want 99", pac.AssocID)
+	}
+}
+
+// buildCmsg packs data into a single control message with the given
+// level and type, as syscall.ParseSocketControlMessage expects to find
+// it in the oob buffer returned by recvmsg.
+func buildCmsg(level, typ int, data []byte) []byte {
+	buf := make([]byte, syscall.CmsgSpace(len(data)))
+	h := (*syscall.Cmsghdr)(unsafe.Pointer(&buf[0]))
+	h.Level = int32(level)
+	h.Type = int32(typ)
+	h.SetLen(syscall.CmsgLen(len(data)))
+	copy(buf[syscall.CmsgLen(0):], data)
+	return buf
+}
+
+// TestMarshalSndInfo checks that marshalSndInfo encodes an SndInfo as
+// a well-formed SCTP_SNDINFO cmsg carrying its fields unchanged.
+func TestMarshalSndInfo(t *testing.T) {
+	info := SndInfo{Stream: 3, Flags: 1, PPID: 42, Context: 7, AssocID: 99}
+	buf := marshalSndInfo(info)
+	h := (*syscall.Cmsghdr)(unsafe.Pointer(&buf[0]))
+	if int(h.Level) != syscall.IPPROTO_SCTP || int(h.Type) != syscall.SCTP_SNDINFO {
+		t.Fatalf("cmsg header = %+v, want level %d type %d", h, syscall.IPPROTO_SCTP, syscall.SCTP_SNDINFO)
+	}
+	si := (*sysSndInfo)(unsafe.Pointer(&buf[syscall.CmsgLen(0)]))
+	want := sysSndInfo{Stream: info.Stream, Flags: info.Flags, PPID: info.PPID, Context: info.Context, AssocID: info.AssocID}
+	if *si != want {
+		t.Errorf("decoded sysSndInfo = %+v, want %+v", *si, want)
+	}
+}
+
+// TestParseSndRcvInfo decodes hand-built SCTP_SNDRCV and SCTP_RCVINFO
+// ancillary messages, using distinct TSN and CumTSN values in each so
+// that a field mix-up between them (as in the SCTP_RCVINFO branch,
+// which once returned CumTSN where TSN was wanted) shows up as a
+// mismatch rather than passing by coincidence.
+func TestParseSndRcvInfo(t *testing.T) {
+	t.Run("SCTP_SNDRCV", func(t *testing.T) {
+		raw := sysSndRcvInfo{
+			Stream: 1, SSN: 2, Flags: 3, PPID: 4, Context: 5,
+			Timetolive: 6, TSN: 111, CumTSN: 222, AssocID: 7,
+		}
+		data := (*[unsafe.Sizeof(raw)]byte)(unsafe.Pointer(&raw))[:]
+		oob := buildCmsg(syscall.IPPROTO_SCTP, syscall.SCTP_SNDRCV, data)
+		got, err := parseSndRcvInfo(oob)
+		if err != nil {
+			t.Fatalf("parseSndRcvInfo: %v", err)
+		}
+		want := SndRcvInfo{Stream: 1, SSN: 2, Flags: 3, PPID: 4, Context: 5, TSN: 111, AssocID: 7}
+		if got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+	t.Run("SCTP_RCVINFO", func(t *testing.T) {
+		raw := sysRcvInfo{
+			Stream: 1, SSN: 2, Flags: 3, PPID: 4,
+			TSN: 333, CumTSN: 444, Context: 5, AssocID: 6,
+		}
+		data := (*[unsafe.Sizeof(raw)]byte)(unsafe.Pointer(&raw))[:]
+		oob := buildCmsg(syscall.IPPROTO_SCTP, syscall.SCTP_RCVINFO, data)
+		got, err := parseSndRcvInfo(oob)
+		if err != nil {
+			t.Fatalf("parseSndRcvInfo: %v", err)
+		}
+		want := SndRcvInfo{Stream: 1, SSN: 2, Flags: 3, PPID: 4, Context: 5, TSN: 333, AssocID: 6}
+		if got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+}
+
+// TestParseSCTPNotificationTruncated checks that parseSCTPNotification
+// rejects a buffer that carries a valid header but is too short for
+// the per-type struct the header's Type selects, instead of
+// unsafe-casting past the end of the buffer's backing array.
+func TestParseSCTPNotificationTruncated(t *testing.T) {
+	var hdr sysNotificationHeader
+	hdr.Type = syscall.SCTP_ASSOC_CHANGE
+	b := (*[unsafe.Sizeof(hdr)]byte)(unsafe.Pointer(&hdr))[:]
+	if _, err := parseSCTPNotification(b); err != syscall.EINVAL {
+		t.Errorf("parseSCTPNotification(%d-byte buf) = %v, want %v", len(b), err, syscall.EINVAL)
+	}
+}
+
+// TestResolveSCTPAddrMultihomed checks the multi-homed comma-list form
+// documented on ResolveSCTPAddr, including a port on the first entry
+// being reused by a later, port-less entry.
+func TestResolveSCTPAddrMultihomed(t *testing.T) {
+	a, err := ResolveSCTPAddr("sctp", "127.0.0.1:7777,127.0.0.2")
+	if err != nil {
+		t.Fatalf("ResolveSCTPAddr: %v", err)
+	}
+	if a.Port != 7777 {
+		t.Errorf("Port = %d, want 7777", a.Port)
+	}
+	if len(a.IPs) != 2 {
+		t.Fatalf("len(IPs) = %d, want 2", len(a.IPs))
+	}
+	if !a.IPs[0].Equal(IPv4(127, 0, 0, 1)) || !a.IPs[1].Equal(IPv4(127, 0, 0, 2)) {
+		t.Errorf("IPs = %v, want [127.0.0.1 127.0.0.2]", a.IPs)
+	}
+}
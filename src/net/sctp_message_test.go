@@ -0,0 +1,70 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build freebsd linux netbsd openbsd
+
+package net
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+// TestSCTPMessageConnRoundTrip dials a real SOCK_SEQPACKET association
+// and checks that SCTPWrite's SndInfo and SCTPRead's SndRcvInfo agree
+// on the stream a message was sent and received on, end to end through
+// marshalSndInfo and parseSndRcvInfo.
+func TestSCTPMessageConnRoundTrip(t *testing.T) {
+	switch runtime.GOOS {
+	case "android", "darwin", "dragonfly", "plan9", "solaris", "nacl", "windows":
+		t.Skipf("not supported on %s", runtime.GOOS)
+	}
+
+	ln, err := ListenSCTPMessage("sctp", &SCTPAddr{IPs: []IP{IPv4(127, 0, 0, 1)}})
+	if err != nil {
+		t.Fatalf("ListenSCTPMessage: %v", err)
+	}
+	defer ln.Close()
+
+	const msg = "hello"
+	const stream = 2
+
+	errc := make(chan error, 1)
+	go func() {
+		c, err := ln.AcceptSCTPMessage()
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer c.Close()
+		b := make([]byte, 64)
+		n, info, err := c.SCTPRead(b)
+		if err != nil {
+			errc <- err
+			return
+		}
+		if got := string(b[:n]); got != msg {
+			errc <- errors.New("SCTPRead data = " + got + ", want " + msg)
+			return
+		}
+		if info.Stream != stream {
+			errc <- errors.New("SCTPRead info.Stream mismatch")
+			return
+		}
+		errc <- nil
+	}()
+
+	c, err := DialSCTPMessage("sctp", nil, ln.Addr().(*SCTPAddr))
+	if err != nil {
+		t.Fatalf("DialSCTPMessage: %v", err)
+	}
+	defer c.Close()
+	if _, err := c.SCTPWrite([]byte(msg), SndInfo{Stream: stream}); err != nil {
+		t.Fatalf("SCTPWrite: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
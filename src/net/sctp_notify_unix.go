@@ -0,0 +1,199 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build freebsd linux netbsd openbsd
+
+package net
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// sysNotificationHeader mirrors the sn_header common to every member of
+// union sctp_notification.
+type sysNotificationHeader struct {
+	Type   uint16
+	Flags  uint16
+	Length uint32
+}
+
+type sysAssocChange struct {
+	Header          sysNotificationHeader
+	State           uint16
+	Error           uint16
+	OutboundStreams uint16
+	InboundStreams  uint16
+	AssocID         int32
+}
+
+// sockaddrStorageSize is sizeof(struct sockaddr_storage): large enough
+// to hold a sockaddr_in or sockaddr_in6, and the size the kernel always
+// uses for addresses embedded in SCTP notifications and getsockopt
+// results, regardless of the address's actual family.
+const sockaddrStorageSize = 128
+
+type sysPeerAddrChange struct {
+	Header  sysNotificationHeader
+	Addr    [sockaddrStorageSize]byte
+	State   int32
+	Error   int32
+	AssocID int32
+}
+
+type sysRemoteError struct {
+	Header  sysNotificationHeader
+	Error   uint16
+	_       uint16
+	AssocID int32
+}
+
+type sysSendFailed struct {
+	Header  sysNotificationHeader
+	Error   uint32
+	Info    sysSndRcvInfo
+	AssocID int32
+}
+
+type sysShutdownEvent struct {
+	Header  sysNotificationHeader
+	AssocID int32
+}
+
+type sysAdaptationEvent struct {
+	Header        sysNotificationHeader
+	AdaptationInd uint32
+	AssocID       int32
+}
+
+type sysPdEvent struct {
+	Header     sysNotificationHeader
+	Indication uint32
+	AssocID    int32
+}
+
+func subscribeSCTPEvents(fd *netFD, e SCTPEventSubscribe) error {
+	buf := []byte{
+		bval(e.DataIO),
+		bval(e.Association),
+		bval(e.Address),
+		bval(e.SendFailure),
+		bval(e.PeerError),
+		bval(e.Shutdown),
+		bval(e.PartialDelivery),
+		bval(e.AdaptationLayer),
+	}
+	if err := fd.incref(); err != nil {
+		return err
+	}
+	defer fd.decref()
+	return wrapSyscallError("setsockopt", syscall.SetsockoptString(fd.sysfd, syscall.IPPROTO_SCTP, syscall.SCTP_EVENTS, string(buf)))
+}
+
+func bval(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+const sctpNotificationBufSize = 4096
+
+// recvSCTPNotification reads the next message on fd. If it is flagged
+// MSG_NOTIFICATION it is decoded and returned as note; otherwise it is
+// ordinary user data and returned as data so the caller can hand it
+// back to Read instead of dropping it.
+func recvSCTPNotification(fd *netFD) (note SCTPNotification, data []byte, err error) {
+	buf := make([]byte, sctpNotificationBufSize)
+	n, _, flags, _, err := fd.readMsg(buf, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if flags&syscall.MSG_NOTIFICATION == 0 {
+		return nil, buf[:n], nil
+	}
+	note, err = parseSCTPNotification(buf[:n])
+	return note, nil, err
+}
+
+func parseSCTPNotification(b []byte) (SCTPNotification, error) {
+	if len(b) < int(unsafe.Sizeof(sysNotificationHeader{})) {
+		return nil, syscall.EINVAL
+	}
+	hdr := (*sysNotificationHeader)(unsafe.Pointer(&b[0]))
+	switch int(hdr.Type) {
+	case syscall.SCTP_ASSOC_CHANGE:
+		if len(b) < int(unsafe.Sizeof(sysAssocChange{})) {
+			return nil, syscall.EINVAL
+		}
+		raw := (*sysAssocChange)(unsafe.Pointer(&b[0]))
+		return AssocChange{
+			State:           raw.State,
+			Error:           raw.Error,
+			OutboundStreams: raw.OutboundStreams,
+			InboundStreams:  raw.InboundStreams,
+			AssocID:         raw.AssocID,
+		}, nil
+	case syscall.SCTP_PEER_ADDR_CHANGE:
+		if len(b) < int(unsafe.Sizeof(sysPeerAddrChange{})) {
+			return nil, syscall.EINVAL
+		}
+		raw := (*sysPeerAddrChange)(unsafe.Pointer(&b[0]))
+		return PeerAddrChange{
+			Addr:    ipFromSockaddrStorage(raw.Addr[:]),
+			State:   raw.State,
+			Error:   raw.Error,
+			AssocID: raw.AssocID,
+		}, nil
+	case syscall.SCTP_REMOTE_ERROR:
+		if len(b) < int(unsafe.Sizeof(sysRemoteError{})) {
+			return nil, syscall.EINVAL
+		}
+		raw := (*sysRemoteError)(unsafe.Pointer(&b[0]))
+		return RemoteError{
+			Error:   raw.Error,
+			Data:    append([]byte(nil), b[unsafe.Sizeof(*raw):]...),
+			AssocID: raw.AssocID,
+		}, nil
+	case syscall.SCTP_SEND_FAILED:
+		if len(b) < int(unsafe.Sizeof(sysSendFailed{})) {
+			return nil, syscall.EINVAL
+		}
+		raw := (*sysSendFailed)(unsafe.Pointer(&b[0]))
+		return SendFailed{
+			Error: raw.Error,
+			Info: SndRcvInfo{
+				Stream:  raw.Info.Stream,
+				SSN:     raw.Info.SSN,
+				Flags:   raw.Info.Flags,
+				PPID:    raw.Info.PPID,
+				Context: raw.Info.Context,
+				TSN:     raw.Info.TSN,
+				AssocID: raw.Info.AssocID,
+			},
+			Data:    append([]byte(nil), b[unsafe.Sizeof(*raw):]...),
+			AssocID: raw.AssocID,
+		}, nil
+	case syscall.SCTP_SHUTDOWN_EVENT:
+		if len(b) < int(unsafe.Sizeof(sysShutdownEvent{})) {
+			return nil, syscall.EINVAL
+		}
+		raw := (*sysShutdownEvent)(unsafe.Pointer(&b[0]))
+		return ShutdownEvent{AssocID: raw.AssocID}, nil
+	case syscall.SCTP_ADAPTATION_INDICATION:
+		if len(b) < int(unsafe.Sizeof(sysAdaptationEvent{})) {
+			return nil, syscall.EINVAL
+		}
+		raw := (*sysAdaptationEvent)(unsafe.Pointer(&b[0]))
+		return AdaptationEvent{AdaptationInd: raw.AdaptationInd, AssocID: raw.AssocID}, nil
+	case syscall.SCTP_PARTIAL_DELIVERY_EVENT:
+		if len(b) < int(unsafe.Sizeof(sysPdEvent{})) {
+			return nil, syscall.EINVAL
+		}
+		raw := (*sysPdEvent)(unsafe.Pointer(&b[0]))
+		return PartialDeliveryEvent{Indication: raw.Indication, AssocID: raw.AssocID}, nil
+	default:
+		return nil, syscall.EINVAL
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"syscall"
+)
+
+// SndInfo carries the per-message metadata passed to SCTPWrite: which
+// stream the message belongs to, its payload protocol identifier and
+// delivery flags such as SCTP_UNORDERED.
+type SndInfo struct {
+	Stream  uint16
+	Flags   uint16
+	PPID    uint32
+	Context uint32
+	AssocID int32
+}
+
+// SndRcvInfo carries the per-message metadata returned by SCTPRead: the
+// stream and its sequence number, the delivery flags the sender set
+// (SCTP_UNORDERED, SCTP_EOF, SCTP_ABORT), the payload protocol
+// identifier and the transmission sequence number the message arrived
+// with.
+type SndRcvInfo struct {
+	Stream  uint16
+	SSN     uint16
+	Flags   uint16
+	PPID    uint32
+	Context uint32
+	TSN     uint32
+	AssocID int32
+}
+
+// SCTPMessageConn is a message-oriented SCTP connection. Unlike
+// SCTPConn, which presents SCTP as a byte stream, SCTPMessageConn is
+// built on SOCK_SEQPACKET and preserves SCTP's native message
+// boundaries, streams and unordered delivery.
+type SCTPMessageConn struct {
+	conn
+}
+
+// SCTPWrite sends b as a single SCTP message carrying info, via
+// sendmsg with an SCTP_SNDINFO ancillary message.
+func (c *SCTPMessageConn) SCTPWrite(b []byte, info SndInfo) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := sendmsgSCTP(c.fd, b, info)
+	if err != nil {
+		return n, &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, nil
+}
+
+// SCTPRead reads the next SCTP message into b and returns its length
+// together with the SndRcvInfo the kernel delivered alongside it via
+// recvmsg.
+func (c *SCTPMessageConn) SCTPRead(b []byte) (int, SndRcvInfo, error) {
+	if !c.ok() {
+		return 0, SndRcvInfo{}, syscall.EINVAL
+	}
+	n, info, err := recvmsgSCTP(c.fd, b)
+	if err != nil {
+		return n, info, &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, info, nil
+}
+
+func newSCTPMessageConn(fd *netFD) *SCTPMessageConn {
+	c := &SCTPMessageConn{conn{fd}}
+	setSCTPNoDelay(c.fd, true)
+	// The kernel attaches the SCTP_SNDRCV ancillary data that SCTPRead
+	// decodes only once data I/O events are subscribed to.
+	subscribeSCTPEvents(c.fd, SCTPEventSubscribe{DataIO: true})
+	return c
+}
+
+// sctpSeqpacketNetwork reports whether net names the message-oriented
+// flavor of a SCTP network ("sctp-seqpacket", "sctp4-seqpacket" or
+// "sctp6-seqpacket") and, if so, returns the underlying byte-stream
+// network name ("sctp", "sctp4" or "sctp6") that the rest of this
+// package's SCTP plumbing already understands.
+func sctpSeqpacketNetwork(net string) (string, bool) {
+	switch net {
+	case "sctp-seqpacket":
+		return "sctp", true
+	case "sctp4-seqpacket":
+		return "sctp4", true
+	case "sctp6-seqpacket":
+		return "sctp6", true
+	default:
+		return net, false
+	}
+}
+
+// DialSCTPMessage connects to the remote address raddr on the network
+// net, which must be "sctp", "sctp4", "sctp6", or one of their
+// "-seqpacket" aliases ("sctp-seqpacket", "sctp4-seqpacket",
+// "sctp6-seqpacket"), using SCTP's message-oriented one-to-many style
+// (SOCK_SEQPACKET) socket. If laddr is not nil, it is used as the local address for the
+// connection.
+func DialSCTPMessage(net string, laddr, raddr *SCTPAddr) (*SCTPMessageConn, error) {
+	dialNet, _ := sctpSeqpacketNetwork(net)
+	switch dialNet {
+	case "sctp", "sctp4", "sctp6":
+	default:
+		return nil, &OpError{Op: "dial", Net: net, Source: laddr.opAddr(), Addr: raddr.opAddr(), Err: UnknownNetworkError(net)}
+	}
+	if raddr == nil {
+		return nil, &OpError{Op: "dial", Net: net, Source: laddr.opAddr(), Addr: nil, Err: errMissingAddress}
+	}
+	c, err := dialSCTPMessage(context.Background(), dialNet, laddr, raddr)
+	if err != nil {
+		return nil, &OpError{Op: "dial", Net: net, Source: laddr.opAddr(), Addr: raddr.opAddr(), Err: err}
+	}
+	return c, nil
+}
+
+// SCTPMessageListener is a message-oriented SCTP network listener. It
+// hands out *SCTPMessageConn values instead of the byte-stream
+// *SCTPConn that SCTPListener produces.
+type SCTPMessageListener struct {
+	fd *netFD
+}
+
+func (l *SCTPMessageListener) ok() bool { return l != nil && l.fd != nil }
+
+// AcceptSCTPMessage accepts the next incoming association and returns
+// it as a message-oriented connection.
+func (l *SCTPMessageListener) AcceptSCTPMessage() (*SCTPMessageConn, error) {
+	if !l.ok() {
+		return nil, syscall.EINVAL
+	}
+	c, err := l.accept()
+	if err != nil {
+		return nil, &OpError{Op: "accept", Net: l.fd.net, Source: nil, Addr: l.fd.laddr, Err: err}
+	}
+	return c, nil
+}
+
+// Accept implements the Accept method in the Listener interface; it
+// waits for the next call and returns a generic Conn.
+func (l *SCTPMessageListener) Accept() (Conn, error) {
+	return l.AcceptSCTPMessage()
+}
+
+// Close stops listening on the SCTP address.
+func (l *SCTPMessageListener) Close() error {
+	if !l.ok() {
+		return syscall.EINVAL
+	}
+	if err := l.fd.Close(); err != nil {
+		return &OpError{Op: "close", Net: l.fd.net, Source: nil, Addr: l.fd.laddr, Err: err}
+	}
+	return nil
+}
+
+// Addr returns the listener's network address, a *SCTPAddr.
+func (l *SCTPMessageListener) Addr() Addr { return l.fd.laddr }
+
+// ListenSCTPMessage announces on the SCTP address laddr and returns a
+// message-oriented SCTP listener built on SOCK_SEQPACKET. Net must be
+// "sctp", "sctp4", "sctp6", or one of their "-seqpacket" aliases
+// ("sctp-seqpacket", "sctp4-seqpacket", "sctp6-seqpacket").
+func ListenSCTPMessage(net string, laddr *SCTPAddr) (*SCTPMessageListener, error) {
+	listenNet, _ := sctpSeqpacketNetwork(net)
+	switch listenNet {
+	case "sctp", "sctp4", "sctp6":
+	default:
+		return nil, &OpError{Op: "listen", Net: net, Source: nil, Addr: laddr.opAddr(), Err: UnknownNetworkError(net)}
+	}
+	if laddr == nil {
+		laddr = &SCTPAddr{}
+	}
+	ln, err := listenSCTPMessage(context.Background(), listenNet, laddr)
+	if err != nil {
+		return nil, &OpError{Op: "listen", Net: net, Source: nil, Addr: laddr.opAddr(), Err: err}
+	}
+	return ln, nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build freebsd linux netbsd openbsd
+
+package net
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func dialSCTPMessage(ctx context.Context, net string, laddr, raddr *SCTPAddr) (*SCTPMessageConn, error) {
+	fd, err := internetSocket(ctx, net, laddr, raddr, syscall.SOCK_SEQPACKET, syscall.IPPROTO_SCTP, "dial", nil)
+	if err != nil {
+		return nil, err
+	}
+	if raddr != nil && len(raddr.IPs) > 1 {
+		if err := connectxSCTP(fd, raddr.IPs[1:], raddr.Port, raddr.family(), raddr.Zone); err != nil {
+			fd.Close()
+			return nil, err
+		}
+	}
+	return newSCTPMessageConn(fd), nil
+}
+
+func listenSCTPMessage(ctx context.Context, network string, laddr *SCTPAddr) (*SCTPMessageListener, error) {
+	fd, err := internetSocket(ctx, network, laddr, nil, syscall.SOCK_SEQPACKET, syscall.IPPROTO_SCTP, "listen", nil)
+	if err != nil {
+		return nil, err
+	}
+	if laddr != nil && len(laddr.IPs) > 1 {
+		if err := bindxSCTP(fd, laddr.IPs[1:], laddr.Port, laddr.family(), laddr.Zone, true); err != nil {
+			fd.Close()
+			return nil, err
+		}
+	}
+	return &SCTPMessageListener{fd}, nil
+}
+
+func (l *SCTPMessageListener) accept() (*SCTPMessageConn, error) {
+	fd, err := l.fd.accept()
+	if err != nil {
+		return nil, err
+	}
+	return newSCTPMessageConn(fd), nil
+}
+
+// sysPeeloffArg mirrors struct sctp_peeloff_arg, the argument to
+// SCTP_SOCKOPT_PEELOFF: the association to peel off, and, on return,
+// the descriptor of the new socket the kernel created for it.
+type sysPeeloffArg struct {
+	AssocID int32
+	SD      int32
+}
+
+// Peeloff converts the association identified by assocID into its own
+// one-to-one style socket via SCTP_SOCKOPT_PEELOFF, and wraps it as an
+// SCTPConn with its own fd registered with the runtime poller. The
+// parent SCTPMessageConn, and any other association multiplexed on it,
+// are unaffected; closing the returned SCTPConn closes only the peeled
+// off association.
+func (c *SCTPMessageConn) Peeloff(assocID int32) (*SCTPConn, error) {
+	if !c.ok() {
+		return nil, syscall.EINVAL
+	}
+	sd, err := peeloffSCTP(c.fd, assocID)
+	if err != nil {
+		return nil, &OpError{Op: "peeloff", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	nfd, err := newFD(sd, c.fd.family, syscall.SOCK_STREAM, c.fd.net)
+	if err != nil {
+		syscall.Close(sd)
+		return nil, &OpError{Op: "peeloff", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	if err := nfd.init(); err != nil {
+		nfd.Close()
+		return nil, &OpError{Op: "peeloff", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	if lsa, err := syscall.Getsockname(sd); err == nil {
+		nfd.laddr = sockaddrToSCTP(lsa)
+	}
+	if rsa, err := syscall.Getpeername(sd); err == nil {
+		nfd.raddr = sockaddrToSCTP(rsa)
+	}
+	return newSCTPConn(nfd), nil
+}
+
+func peeloffSCTP(fd *netFD, assocID int32) (int, error) {
+	if err := fd.incref(); err != nil {
+		return -1, err
+	}
+	defer fd.decref()
+	arg := sysPeeloffArg{AssocID: assocID}
+	optlen := unsafe.Sizeof(arg)
+	_, _, errno := syscall.Syscall6(syscall.SYS_GETSOCKOPT, uintptr(fd.sysfd), uintptr(syscall.IPPROTO_SCTP), uintptr(syscall.SCTP_SOCKOPT_PEELOFF), uintptr(unsafe.Pointer(&arg)), uintptr(unsafe.Pointer(&optlen)), 0)
+	if errno != 0 {
+		return -1, os.NewSyscallError("getsockopt", errno)
+	}
+	return int(arg.SD), nil
+}
+
+// sendmsgSCTP sends b as a single SCTP message described by info via
+// sendmsg, attaching info as an SCTP_SNDINFO ancillary message.
+func sendmsgSCTP(fd *netFD, b []byte, info SndInfo) (int, error) {
+	oob := marshalSndInfo(info)
+	n, _, err := fd.writeMsg(b, oob, nil)
+	if err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// recvmsgSCTP reads the next SCTP message into b via recvmsg and
+// decodes the SCTP_SNDRCV/SCTP_RCVINFO ancillary data the kernel
+// attaches to it.
+func recvmsgSCTP(fd *netFD, b []byte) (int, SndRcvInfo, error) {
+	oob := make([]byte, sctpCmsgSpace)
+	n, oobn, _, _, err := fd.readMsg(b, oob)
+	if err != nil {
+		return n, SndRcvInfo{}, err
+	}
+	info, err := parseSndRcvInfo(oob[:oobn])
+	if err != nil {
+		return n, SndRcvInfo{}, err
+	}
+	return n, info, nil
+}
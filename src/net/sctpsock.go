@@ -8,17 +8,31 @@ import (
 	"context"
 	"io"
 	"os"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
 
-// SCTPAddr represents the address of a SCTP end point.
+// SCTPAddr represents the address of a SCTP end point. Unlike most other
+// network address types, a SCTPAddr may carry more than one IP: SCTP
+// associations are multi-homed, meaning each endpoint can be reached
+// through several local or peer addresses at once.
 type SCTPAddr struct {
-	IP   IP
+	IPs  []IP
 	Port int
 	Zone string // IPv6 scoped addressing zone
 }
 
+// IP returns the first address of a, or nil if a has none. It exists for
+// callers that only care about a single representative address.
+func (a *SCTPAddr) IP() IP {
+	if a == nil || len(a.IPs) == 0 {
+		return nil
+	}
+	return a.IPs[0]
+}
+
 // Network returns the address's network name, "sctp".
 func (a *SCTPAddr) Network() string { return "sctp" }
 
@@ -26,7 +40,7 @@ func (a *SCTPAddr) String() string {
 	if a == nil {
 		return "<nil>"
 	}
-	ip := ipEmptyString(a.IP)
+	ip := ipEmptyString(a.IP())
 	if a.Zone != "" {
 		return JoinHostPort(ip+"%"+a.Zone, itoa(a.Port))
 	}
@@ -34,10 +48,10 @@ func (a *SCTPAddr) String() string {
 }
 
 func (a *SCTPAddr) isWildcard() bool {
-	if a == nil || a.IP == nil {
+	if a == nil || len(a.IPs) == 0 {
 		return true
 	}
-	return a.IP.IsUnspecified()
+	return a.IP().IsUnspecified()
 }
 
 func (a *SCTPAddr) opAddr() Addr {
@@ -53,6 +67,11 @@ func (a *SCTPAddr) opAddr() Addr {
 // "sctp6".  A literal address or host name for IPv6 must be enclosed
 // in square brackets, as in "[::1]:80", "[ipv6-host]:http" or
 // "[ipv6-host%zone]:80".
+//
+// address may also name several local addresses of a multi-homed
+// endpoint as a comma-separated list, such as "10.0.0.1:7777,10.0.0.2".
+// Only the first entry needs to carry a port; later entries that omit
+// one reuse the port resolved from the first.
 func ResolveSCTPAddr(network, address string) (*SCTPAddr, error) {
 	switch network {
 	case "sctp", "sctp4", "sctp6":
@@ -61,17 +80,38 @@ func ResolveSCTPAddr(network, address string) (*SCTPAddr, error) {
 	default:
 		return nil, UnknownNetworkError(network)
 	}
-	addrs, err := DefaultResolver.internetAddrList(context.Background(), network, address)
-	if err != nil {
-		return nil, err
-	}
-	return addrs.forResolve(network, address).(*SCTPAddr), nil
+	sa := &SCTPAddr{}
+	for i, part := range strings.Split(address, ",") {
+		if i > 0 {
+			if _, _, err := SplitHostPort(part); err != nil {
+				part = JoinHostPort(strings.Trim(part, "[]"), itoa(sa.Port))
+			}
+		}
+		addrs, err := DefaultResolver.internetAddrList(context.Background(), network, part)
+		if err != nil {
+			return nil, err
+		}
+		a := addrs.forResolve(network, part).(*SCTPAddr)
+		if i == 0 {
+			sa.Port = a.Port
+			sa.Zone = a.Zone
+		}
+		sa.IPs = append(sa.IPs, a.IPs...)
+	}
+	return sa, nil
 }
 
 // SCTPConn is an implementation of the Conn interface for SCTP network
 // connections.
 type SCTPConn struct {
 	conn
+
+	// pendingMu guards pending, which RecvNotification and Read access
+	// from (potentially) separate goroutines.
+	pendingMu sync.Mutex
+	// pending holds user data RecvNotification read ahead of a
+	// notification and has not yet handed back via Read.
+	pending []byte
 }
 
 // ReadFrom implements the io.ReaderFrom ReadFrom method.
@@ -169,16 +209,313 @@ func (c *SCTPConn) SetNoDelay(noDelay bool) error {
 	return nil
 }
 
+// BindAdd adds ips as additional local addresses of the association,
+// extending its multi-homing set via sctp_bindx(SCTP_BINDX_ADD_ADDR).
+func (c *SCTPConn) BindAdd(ips []IP) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	la, ok := c.fd.laddr.(*SCTPAddr)
+	if !ok {
+		return syscall.EINVAL
+	}
+	if err := bindxSCTP(c.fd, ips, la.Port, la.family(), la.Zone, true); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	la.IPs = append(la.IPs, ips...)
+	return nil
+}
+
+// BindRemove removes ips from the local addresses of the association
+// via sctp_bindx(SCTP_BINDX_REM_ADDR).
+func (c *SCTPConn) BindRemove(ips []IP) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	la, ok := c.fd.laddr.(*SCTPAddr)
+	if !ok {
+		return syscall.EINVAL
+	}
+	if err := bindxSCTP(c.fd, ips, la.Port, la.family(), la.Zone, false); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	remaining := la.IPs[:0]
+	for _, ip := range la.IPs {
+		removed := false
+		for _, r := range ips {
+			if ip.Equal(r) {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			remaining = append(remaining, ip)
+		}
+	}
+	la.IPs = remaining
+	return nil
+}
+
+// GetPeerAddrs returns the addresses of the remote end of the
+// association, as reported by the kernel via SCTP_GET_PEER_ADDRS.
+func (c *SCTPConn) GetPeerAddrs() ([]IP, error) {
+	if !c.ok() {
+		return nil, syscall.EINVAL
+	}
+	ips, err := getSCTPAddrs(c.fd, syscall.SCTP_GET_PEER_ADDRS)
+	if err != nil {
+		return nil, &OpError{Op: "get", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return ips, nil
+}
+
+// GetLocalAddrs returns the local addresses bound to the association,
+// as reported by the kernel via SCTP_GET_LOCAL_ADDRS.
+func (c *SCTPConn) GetLocalAddrs() ([]IP, error) {
+	if !c.ok() {
+		return nil, syscall.EINVAL
+	}
+	ips, err := getSCTPAddrs(c.fd, syscall.SCTP_GET_LOCAL_ADDRS)
+	if err != nil {
+		return nil, &OpError{Op: "get", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return ips, nil
+}
+
+// SetRtoInfo sets the association's retransmission timeout bounds via
+// SCTP_RTOINFO: initial is the RTO used before any round-trip has been
+// measured, and max/min clamp the RTO as it adapts. Shortening these,
+// together with SetPeerAddrParams's heartbeat interval, is the usual
+// way to speed up failover to a secondary address in a multi-homed
+// deployment.
+func (c *SCTPConn) SetRtoInfo(initial, max, min time.Duration) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setSCTPRtoInfo(c.fd, initial, max, min); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// SCTPAssocParams carries the SCTP_ASSOCINFO parameters of an
+// association: the association-wide maximum retransmission count (the
+// per-path threshold is set by SetPeerAddrParams), the number of peer
+// destinations, the send/receive window sizes, and the lifetime of the
+// association's cookie.
+type SCTPAssocParams struct {
+	MaxRetrans             uint16
+	NumberPeerDestinations uint16
+	PeerRwnd               uint32
+	LocalRwnd              uint32
+	CookieLife             time.Duration
+}
+
+// SetAssocParams sets the association's tunable parameters via
+// SCTP_ASSOCINFO.
+func (c *SCTPConn) SetAssocParams(p SCTPAssocParams) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setSCTPAssocParams(c.fd, p); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// SetPeerAddrParams tunes the path to addr, one of the association's
+// peer addresses, via SCTP_PEER_ADDR_PARAMS: hbInterval controls how
+// often heartbeats probe the path, pathMaxRxt is the number of missed
+// heartbeats/retransmissions before the path is considered down, and
+// enableHB turns heartbeating to this path on or off.
+func (c *SCTPConn) SetPeerAddrParams(addr IP, hbInterval time.Duration, pathMaxRxt uint16, enableHB bool) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setSCTPPeerAddrParams(c.fd, addr, hbInterval, pathMaxRxt, enableHB); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// SCTPEventSubscribe selects which out-of-band notifications a
+// SCTPConn should receive, via SCTP_EVENTS. Each field corresponds to
+// one class of notification; RecvNotification only ever returns the
+// concrete types backed by a class that was subscribed to.
+type SCTPEventSubscribe struct {
+	DataIO          bool
+	Association     bool
+	Address         bool
+	SendFailure     bool
+	PeerError       bool
+	Shutdown        bool
+	PartialDelivery bool
+	AdaptationLayer bool
+}
+
+// SCTPNotification is implemented by the concrete notification types
+// SCTP delivers out-of-band from ordinary data: AssocChange,
+// PeerAddrChange, RemoteError, SendFailed, ShutdownEvent,
+// AdaptationEvent and PartialDeliveryEvent.
+type SCTPNotification interface {
+	sctpNotification()
+}
+
+// AssocChange reports a change in the state of an association, such as
+// COMM_UP or COMM_LOST.
+type AssocChange struct {
+	State           uint16
+	Error           uint16
+	OutboundStreams uint16
+	InboundStreams  uint16
+	AssocID         int32
+}
+
+func (AssocChange) sctpNotification() {}
+
+// PeerAddrChange reports that one of the peer's addresses changed
+// reachability state, e.g. after a heartbeat timeout promotes a
+// secondary address to primary in a multi-homed association.
+type PeerAddrChange struct {
+	Addr    IP
+	State   int32
+	Error   int32
+	AssocID int32
+}
+
+func (PeerAddrChange) sctpNotification() {}
+
+// RemoteError reports an operational error the peer sent.
+type RemoteError struct {
+	Error   uint16
+	Data    []byte
+	AssocID int32
+}
+
+func (RemoteError) sctpNotification() {}
+
+// SendFailed reports that a previously sent message could not be
+// delivered.
+type SendFailed struct {
+	Error   uint32
+	Info    SndRcvInfo
+	Data    []byte
+	AssocID int32
+}
+
+func (SendFailed) sctpNotification() {}
+
+// ShutdownEvent reports that the peer has begun shutting down the
+// association.
+type ShutdownEvent struct {
+	AssocID int32
+}
+
+func (ShutdownEvent) sctpNotification() {}
+
+// AdaptationEvent reports the adaptation layer indication the peer
+// advertised during association setup.
+type AdaptationEvent struct {
+	AdaptationInd uint32
+	AssocID       int32
+}
+
+func (AdaptationEvent) sctpNotification() {}
+
+// PartialDeliveryEvent reports a change in the partial delivery state
+// of the stream currently being read in partial-delivery mode.
+type PartialDeliveryEvent struct {
+	Indication uint32
+	AssocID    int32
+}
+
+func (PartialDeliveryEvent) sctpNotification() {}
+
+// SubscribeEvents selects which notifications the kernel delivers for
+// this association; RecvNotification only returns notifications from a
+// subscribed class.
+func (c *SCTPConn) SubscribeEvents(events SCTPEventSubscribe) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := subscribeSCTPEvents(c.fd, events); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// RecvNotification waits for and returns the next notification
+// subscribed to via SubscribeEvents. Ordinary data that arrives while
+// waiting is not discarded: it is buffered and returned by the next
+// call to Read, so RecvNotification can safely be called from a
+// separate goroutine than Read without corrupting the byte stream.
+func (c *SCTPConn) RecvNotification() (SCTPNotification, error) {
+	if !c.ok() {
+		return nil, syscall.EINVAL
+	}
+	for {
+		note, data, err := recvSCTPNotification(c.fd)
+		if err != nil {
+			return nil, &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+		}
+		if note != nil {
+			return note, nil
+		}
+		c.pendingMu.Lock()
+		c.pending = append(c.pending, data...)
+		c.pendingMu.Unlock()
+	}
+}
+
+// Read reads data from the connection, first draining any data that
+// RecvNotification set aside while looking for a notification.
+func (c *SCTPConn) Read(b []byte) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	c.pendingMu.Lock()
+	if len(c.pending) > 0 {
+		n := copy(b, c.pending)
+		c.pending = c.pending[n:]
+		c.pendingMu.Unlock()
+		return n, nil
+	}
+	c.pendingMu.Unlock()
+	return c.conn.Read(b)
+}
+
 func newSCTPConn(fd *netFD) *SCTPConn {
-	c := &SCTPConn{conn{fd}}
+	c := &SCTPConn{conn: conn{fd}}
 	setSCTPNoDelay(c.fd, true)
 	return c
 }
 
+// SCTPInitMsg carries the SCTP_INITMSG parameters that shape the INIT
+// chunk of associations made through a socket: the number of outbound
+// streams requested, the maximum number of inbound streams accepted,
+// and the retransmission budget and timeout for the initial handshake.
+type SCTPInitMsg struct {
+	NumOstreams  uint16
+	MaxInstreams uint16
+	MaxAttempts  uint16
+	MaxInitTimeo uint16
+}
+
+// SCTPConfig carries options that must be applied to a socket before
+// its first association is established, and so cannot be expressed as
+// a method on an already-connected SCTPConn.
+type SCTPConfig struct {
+	// InitMsg, if non-nil, is set via SCTP_INITMSG on the raw socket
+	// between its creation and the connect/listen call, so it governs
+	// the very first INIT chunk: for DialSCTP, the dial's own INIT; for
+	// ListenSCTP, every association subsequently accepted on it.
+	InitMsg *SCTPInitMsg
+}
+
 // DialSCTP connects to the remote address raddr on the network net,
 // which must be "sctp", "sctp4", or "sctp6".  If laddr is not nil, it is
-// used as the local address for the connection.
-func DialSCTP(net string, laddr, raddr *SCTPAddr) (*SCTPConn, error) {
+// used as the local address for the connection. cfg may be nil.
+func DialSCTP(net string, laddr, raddr *SCTPAddr, cfg *SCTPConfig) (*SCTPConn, error) {
 	switch net {
 	case "sctp", "sctp4", "sctp6":
 	default:
@@ -187,7 +524,17 @@ func DialSCTP(net string, laddr, raddr *SCTPAddr) (*SCTPConn, error) {
 	if raddr == nil {
 		return nil, &OpError{Op: "dial", Net: net, Source: laddr.opAddr(), Addr: nil, Err: errMissingAddress}
 	}
-	c, err := dialSCTP(context.Background(), net, laddr, raddr)
+	var c *SCTPConn
+	var err error
+	if cfg != nil && cfg.InitMsg != nil {
+		// dialSCTP (and its testHookDialSCTP) connects the raw socket
+		// immediately, which is too late to apply InitMsg before the
+		// first INIT chunk; go through doDialSCTP directly so cfg can
+		// be threaded into internetSocket's pre-connect control hook.
+		c, err = doDialSCTP(context.Background(), net, laddr, raddr, cfg)
+	} else {
+		c, err = dialSCTP(context.Background(), net, laddr, raddr)
+	}
 	if err != nil {
 		return nil, &OpError{Op: "dial", Net: net, Source: laddr.opAddr(), Addr: raddr.opAddr(), Err: err}
 	}
@@ -277,7 +624,8 @@ func (l *SCTPListener) File() (f *os.File, err error) {
 // listener. Net must be "sctp", "sctp4", or "sctp6".  If laddr has a
 // port of 0, ListenSCTP will choose an available port. The caller can
 // use the Addr method of SCTPListener to retrieve the chosen address.
-func ListenSCTP(net string, laddr *SCTPAddr) (*SCTPListener, error) {
+// cfg may be nil.
+func ListenSCTP(net string, laddr *SCTPAddr, cfg *SCTPConfig) (*SCTPListener, error) {
 	switch net {
 	case "sctp", "sctp4", "sctp6":
 	default:
@@ -286,7 +634,7 @@ func ListenSCTP(net string, laddr *SCTPAddr) (*SCTPListener, error) {
 	if laddr == nil {
 		laddr = &SCTPAddr{}
 	}
-	ln, err := listenSCTP(context.Background(), net, laddr)
+	ln, err := listenSCTP(context.Background(), net, laddr, cfg)
 	if err != nil {
 		return nil, &OpError{Op: "listen", Net: net, Source: nil, Addr: laddr.opAddr(), Err: err}
 	}
@@ -11,23 +11,25 @@ import (
 	"io"
 	"os"
 	"syscall"
+	"unsafe"
 )
 
 func sockaddrToSCTP(sa syscall.Sockaddr) Addr {
 	switch sa := sa.(type) {
 	case *syscall.SockaddrInet4:
-		return &SCTPAddr{IP: sa.Addr[0:], Port: sa.Port}
+		return &SCTPAddr{IPs: []IP{sa.Addr[0:]}, Port: sa.Port}
 	case *syscall.SockaddrInet6:
-		return &SCTPAddr{IP: sa.Addr[0:], Port: sa.Port, Zone: zoneCache.name(int(sa.ZoneId))}
+		return &SCTPAddr{IPs: []IP{sa.Addr[0:]}, Port: sa.Port, Zone: zoneCache.name(int(sa.ZoneId))}
 	}
 	return nil
 }
 
 func (a *SCTPAddr) family() int {
-	if a == nil || len(a.IP) <= IPv4len {
+	ip := a.IP()
+	if a == nil || len(ip) <= IPv4len {
 		return syscall.AF_INET
 	}
-	if a.IP.To4() != nil {
+	if ip.To4() != nil {
 		return syscall.AF_INET
 	}
 	return syscall.AF_INET6
@@ -37,11 +39,148 @@ func (a *SCTPAddr) sockaddr(family int) (syscall.Sockaddr, error) {
 	if a == nil {
 		return nil, nil
 	}
-	return ipToSockaddr(family, a.IP, a.Port, a.Zone)
+	return ipToSockaddr(family, a.IP(), a.Port, a.Zone)
 }
 
 func (a *SCTPAddr) toLocal(net string) sockaddr {
-	return &SCTPAddr{loopbackIP(net), a.Port, a.Zone}
+	return &SCTPAddr{IPs: []IP{loopbackIP(net)}, Port: a.Port, Zone: a.Zone}
+}
+
+// marshalSCTPAddr packs sa into the raw sockaddr_in/sockaddr_in6 bytes
+// the kernel expects in the address buffers used by sctp_bindx(3) and
+// sctp_connectx(3).
+func marshalSCTPAddr(sa syscall.Sockaddr) ([]byte, error) {
+	switch sa := sa.(type) {
+	case *syscall.SockaddrInet4:
+		var raw syscall.RawSockaddrInet4
+		raw.Family = syscall.AF_INET
+		raw.Port[0] = byte(sa.Port >> 8)
+		raw.Port[1] = byte(sa.Port)
+		raw.Addr = sa.Addr
+		b := (*[syscall.SizeofSockaddrInet4]byte)(unsafe.Pointer(&raw))
+		return b[:], nil
+	case *syscall.SockaddrInet6:
+		var raw syscall.RawSockaddrInet6
+		raw.Family = syscall.AF_INET6
+		raw.Port[0] = byte(sa.Port >> 8)
+		raw.Port[1] = byte(sa.Port)
+		raw.Scope_id = sa.ZoneId
+		raw.Addr = sa.Addr
+		b := (*[syscall.SizeofSockaddrInet6]byte)(unsafe.Pointer(&raw))
+		return b[:], nil
+	default:
+		return nil, syscall.EINVAL
+	}
+}
+
+// sctpSetAddrs packs ips into a flat buffer of raw sockaddrs and passes
+// it to the SCTP_SOCKOPT_BINDX_* / SCTP_SOCKOPT_CONNECTX setsockopt
+// that implements sctp_bindx(3) / sctp_connectx(3) on fd.
+func sctpSetAddrs(fd *netFD, opt int, ips []IP, port, family int, zone string) error {
+	var buf []byte
+	for _, ip := range ips {
+		sa, err := ipToSockaddr(family, ip, port, zone)
+		if err != nil {
+			return err
+		}
+		b, err := marshalSCTPAddr(sa)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, b...)
+	}
+	if err := fd.incref(); err != nil {
+		return err
+	}
+	defer fd.decref()
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptString(fd.sysfd, syscall.IPPROTO_SCTP, opt, string(buf)))
+}
+
+func bindxSCTP(fd *netFD, ips []IP, port, family int, zone string, add bool) error {
+	opt := syscall.SCTP_SOCKOPT_BINDX_ADD
+	if !add {
+		opt = syscall.SCTP_SOCKOPT_BINDX_REM
+	}
+	return sctpSetAddrs(fd, opt, ips, port, family, zone)
+}
+
+func connectxSCTP(fd *netFD, ips []IP, port, family int, zone string) error {
+	return sctpSetAddrs(fd, syscall.SCTP_SOCKOPT_CONNECTX, ips, port, family, zone)
+}
+
+// sctpGetAddrsParamHeader mirrors the fixed part of struct
+// sctp_getaddrs; the addrs[] member that follows it in the kernel
+// struct is a flexible array embedded in the same buffer, not an
+// out-of-line pointer, so it has no corresponding Go field here. See
+// getSCTPAddrs.
+type sctpGetAddrsParamHeader struct {
+	AssocID int32
+	AddrNum uint32
+}
+
+const sizeofSctpGetAddrsParamHeader = 8
+
+// getSCTPAddrs retrieves the set of addresses reported by opt (one of
+// SCTP_GET_PEER_ADDRS or SCTP_GET_LOCAL_ADDRS) for the association on
+// fd. The kernel writes the sctp_getaddrs header and the addresses
+// themselves back-to-back into a single buffer, so that buffer, not a
+// pointer to a separate one, is what's passed as optval.
+func getSCTPAddrs(fd *netFD, opt int) ([]IP, error) {
+	if err := fd.incref(); err != nil {
+		return nil, err
+	}
+	defer fd.decref()
+
+	const maxAddrs = 64
+	buf := make([]byte, sizeofSctpGetAddrsParamHeader+maxAddrs*sockaddrStorageSize)
+	hdr := (*sctpGetAddrsParamHeader)(unsafe.Pointer(&buf[0]))
+	hdr.AddrNum = maxAddrs
+	optlen := uintptr(len(buf))
+	_, _, errno := syscall.Syscall6(syscall.SYS_GETSOCKOPT, uintptr(fd.sysfd), uintptr(syscall.IPPROTO_SCTP), uintptr(opt), uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&optlen)), 0)
+	if errno != 0 {
+		return nil, os.NewSyscallError("getsockopt", errno)
+	}
+	return parseSCTPGetAddrs(buf[sizeofSctpGetAddrsParamHeader:], int(hdr.AddrNum)), nil
+}
+
+// parseSCTPGetAddrs walks n sockaddr_storage-sized entries packed
+// back-to-back in raw, the layout the kernel uses for the addrs[]
+// member of struct sctp_getaddrs.
+func parseSCTPGetAddrs(raw []byte, n int) []IP {
+	ips := make([]IP, 0, n)
+	for i := 0; i < n && (i+1)*sockaddrStorageSize <= len(raw); i++ {
+		off := i * sockaddrStorageSize
+		ips = append(ips, ipFromSockaddrStorage(raw[off:off+sockaddrStorageSize]))
+	}
+	return ips
+}
+
+// ipFromSockaddrStorage extracts the IP from a sockaddr_storage-sized
+// buffer, the layout the kernel always uses for addresses embedded in
+// SCTP notifications and the SCTP_GET_*_ADDRS results, regardless of
+// the address's actual family.
+func ipFromSockaddrStorage(b []byte) IP {
+	if len(b) == 0 {
+		return nil
+	}
+	switch b[0] {
+	case syscall.AF_INET:
+		if len(b) < syscall.SizeofSockaddrInet4 {
+			return nil
+		}
+		var a syscall.RawSockaddrInet4
+		copy((*[syscall.SizeofSockaddrInet4]byte)(unsafe.Pointer(&a))[:], b)
+		return IP(append([]byte(nil), a.Addr[:]...))
+	case syscall.AF_INET6:
+		if len(b) < syscall.SizeofSockaddrInet6 {
+			return nil
+		}
+		var a syscall.RawSockaddrInet6
+		copy((*[syscall.SizeofSockaddrInet6]byte)(unsafe.Pointer(&a))[:], b)
+		return IP(append([]byte(nil), a.Addr[:]...))
+	default:
+		return nil
+	}
 }
 
 func (c *SCTPConn) readFrom(r io.Reader) (int64, error) {
@@ -55,14 +194,47 @@ func dialSCTP(ctx context.Context, net string, laddr, raddr *SCTPAddr) (*SCTPCon
 	if testHookDialSCTP != nil {
 		return testHookDialSCTP(ctx, net, laddr, raddr)
 	}
-	return doDialSCTP(ctx, net, laddr, raddr)
+	return doDialSCTP(ctx, net, laddr, raddr, nil)
+}
+
+// sctpInitMsgControl returns the pre-connect/pre-listen control hook
+// that applies im to the raw socket before internetSocket connects or
+// listens on it, so it governs the very first INIT chunk. It returns
+// nil if im is nil, meaning no hook is needed.
+func sctpInitMsgControl(im *SCTPInitMsg) func(context.Context, string, string, syscall.RawConn) error {
+	if im == nil {
+		return nil
+	}
+	msg := *im
+	return func(_ context.Context, _, _ string, c syscall.RawConn) error {
+		var serr error
+		if err := c.Control(func(fd uintptr) {
+			serr = setSCTPInitMsgFd(int(fd), msg)
+		}); err != nil {
+			return err
+		}
+		return serr
+	}
 }
 
-func doDialSCTP(ctx context.Context, net string, laddr, raddr *SCTPAddr) (*SCTPConn, error) {
-	fd, err := internetSocket(ctx, net, laddr, raddr, syscall.SOCK_STREAM, syscall.IPPROTO_SCTP, "dial")
+func doDialSCTP(ctx context.Context, net string, laddr, raddr *SCTPAddr, cfg *SCTPConfig) (*SCTPConn, error) {
+	var initMsg *SCTPInitMsg
+	if cfg != nil {
+		initMsg = cfg.InitMsg
+	}
+	fd, err := internetSocket(ctx, net, laddr, raddr, syscall.SOCK_STREAM, syscall.IPPROTO_SCTP, "dial", sctpInitMsgControl(initMsg))
 	if err != nil {
 		return nil, err
 	}
+	// internetSocket already connected to raddr's first address; the
+	// rest of a multi-homed peer are added to the same association via
+	// sctp_connectx.
+	if raddr != nil && len(raddr.IPs) > 1 {
+		if err := connectxSCTP(fd, raddr.IPs[1:], raddr.Port, raddr.family(), raddr.Zone); err != nil {
+			fd.Close()
+			return nil, err
+		}
+	}
 	return newSCTPConn(fd), nil
 }
 
@@ -88,10 +260,22 @@ func (ln *SCTPListener) file() (*os.File, error) {
 	return f, nil
 }
 
-func listenSCTP(ctx context.Context, network string, laddr *SCTPAddr) (*SCTPListener, error) {
-	fd, err := internetSocket(ctx, network, laddr, nil, syscall.SOCK_STREAM, syscall.IPPROTO_SCTP, "listen")
+func listenSCTP(ctx context.Context, network string, laddr *SCTPAddr, cfg *SCTPConfig) (*SCTPListener, error) {
+	var initMsg *SCTPInitMsg
+	if cfg != nil {
+		initMsg = cfg.InitMsg
+	}
+	fd, err := internetSocket(ctx, network, laddr, nil, syscall.SOCK_STREAM, syscall.IPPROTO_SCTP, "listen", sctpInitMsgControl(initMsg))
 	if err != nil {
 		return nil, err
 	}
+	// internetSocket already bound the first address; additional local
+	// addresses of a multi-homed listener are added via sctp_bindx.
+	if laddr != nil && len(laddr.IPs) > 1 {
+		if err := bindxSCTP(fd, laddr.IPs[1:], laddr.Port, laddr.family(), laddr.Zone, true); err != nil {
+			fd.Close()
+			return nil, err
+		}
+	}
 	return &SCTPListener{fd}, nil
 }
@@ -7,12 +7,168 @@
 package net
 
 import (
+	"encoding/binary"
+	"os"
 	"runtime"
 	"syscall"
+	"time"
+	"unsafe"
 )
 
+// nativeEndian is the byte order the kernel uses for the
+// multi-byte fields of SCTP's raw getsockopt/setsockopt structs: the
+// host's own byte order, detected at package initialization.
+var nativeEndian binary.ByteOrder = func() binary.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
 func setSCTPNoDelay(fd *netFD, noDelay bool) error {
 	err := fd.pfd.SetsockoptInt(syscall.IPPROTO_SCTP, syscall.SCTP_NODELAY, boolint(noDelay))
 	runtime.KeepAlive(fd)
 	return wrapSyscallError("setsockopt", err)
 }
+
+// sysInitMsg mirrors struct sctp_initmsg, the argument to SCTP_INITMSG.
+type sysInitMsg struct {
+	NumOstreams  uint16
+	MaxInstreams uint16
+	MaxAttempts  uint16
+	MaxInitTimeo uint16
+}
+
+// sysRtoInfo mirrors struct sctp_rtoinfo, the argument to SCTP_RTOINFO.
+// AssocID is left zero, which the kernel treats as the one association
+// carried by a one-to-one style socket.
+type sysRtoInfo struct {
+	AssocID int32
+	Initial uint32
+	Max     uint32
+	Min     uint32
+}
+
+// sysAssocParams mirrors struct sctp_assocparams, the argument to
+// SCTP_ASSOCINFO.
+type sysAssocParams struct {
+	AssocID                int32
+	AssocMaxRxt            uint16
+	NumberPeerDestinations uint16
+	PeerRwnd               uint32
+	LocalRwnd              uint32
+	CookieLife             uint32
+}
+
+// The kernel's struct sctp_paddrparams is marked __attribute__((packed)),
+// so spp_pathmaxrxt (a uint16) sits directly against spp_pathmtu (the
+// following uint32) with no padding. A naturally-aligned Go struct
+// can't reproduce that layout, so the struct's bytes are built by hand
+// at these fixed byte offsets (see marshalPeerAddrParams) instead of
+// via an unsafe struct cast:
+//
+//	offset 0   AssocID    int32
+//	offset 4   Address    [sockaddrStorageSize]byte
+//	offset 132 HBInterval uint32
+//	offset 136 PathMaxRxt uint16
+//	offset 138 PathMTU    uint32
+//	offset 142 SackDelay  uint32
+//	offset 146 Flags      uint32
+const (
+	peerAddrParamsAssocIDOff    = 0
+	peerAddrParamsAddressOff    = peerAddrParamsAssocIDOff + 4
+	peerAddrParamsHBIntervalOff = peerAddrParamsAddressOff + sockaddrStorageSize
+	peerAddrParamsPathMaxRxtOff = peerAddrParamsHBIntervalOff + 4
+	peerAddrParamsPathMTUOff    = peerAddrParamsPathMaxRxtOff + 2
+	peerAddrParamsSackDelayOff  = peerAddrParamsPathMTUOff + 4
+	peerAddrParamsFlagsOff      = peerAddrParamsSackDelayOff + 4
+	sizeofSysPeerAddrParams     = peerAddrParamsFlagsOff + 4
+)
+
+// marshalPeerAddrParams builds the raw bytes of a struct
+// sctp_paddrparams from its fields, matching the kernel's packed
+// layout described above.
+func marshalPeerAddrParams(assocID int32, address []byte, hbInterval uint32, pathMaxRxt uint16, flags uint32) []byte {
+	b := make([]byte, sizeofSysPeerAddrParams)
+	nativeEndian.PutUint32(b[peerAddrParamsAssocIDOff:], uint32(assocID))
+	copy(b[peerAddrParamsAddressOff:peerAddrParamsAddressOff+sockaddrStorageSize], address)
+	nativeEndian.PutUint32(b[peerAddrParamsHBIntervalOff:], hbInterval)
+	nativeEndian.PutUint16(b[peerAddrParamsPathMaxRxtOff:], pathMaxRxt)
+	nativeEndian.PutUint32(b[peerAddrParamsFlagsOff:], flags)
+	return b
+}
+
+// sctpSetsockopt issues a setsockopt(IPPROTO_SCTP, opt, ...) with the
+// raw bytes of the struct pointed to by p.
+func sctpSetsockopt(fd *netFD, opt int, p unsafe.Pointer, size uintptr) error {
+	if err := fd.incref(); err != nil {
+		return err
+	}
+	defer fd.decref()
+	b := (*[1 << 10]byte)(p)[:size:size]
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptString(fd.sysfd, syscall.IPPROTO_SCTP, opt, string(b)))
+}
+
+// sctpSetsockoptBytes issues a setsockopt(IPPROTO_SCTP, opt, ...) with
+// an already-marshaled byte buffer, for options whose layout can't be
+// expressed as a naturally-aligned Go struct (see marshalPeerAddrParams).
+func sctpSetsockoptBytes(fd *netFD, opt int, b []byte) error {
+	if err := fd.incref(); err != nil {
+		return err
+	}
+	defer fd.decref()
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptString(fd.sysfd, syscall.IPPROTO_SCTP, opt, string(b)))
+}
+
+// setSCTPInitMsgFd sets SCTP_INITMSG on a bare file descriptor, for use
+// from a syscall.RawConn.Control callback during dial/listen setup,
+// before a netFD exists to hang the option off of.
+func setSCTPInitMsgFd(fd int, im SCTPInitMsg) error {
+	raw := sysInitMsg{
+		NumOstreams:  im.NumOstreams,
+		MaxInstreams: im.MaxInstreams,
+		MaxAttempts:  im.MaxAttempts,
+		MaxInitTimeo: im.MaxInitTimeo,
+	}
+	b := (*[unsafe.Sizeof(raw)]byte)(unsafe.Pointer(&raw))[:]
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptString(fd, syscall.IPPROTO_SCTP, syscall.SCTP_INITMSG, string(b)))
+}
+
+func setSCTPRtoInfo(fd *netFD, initial, max, min time.Duration) error {
+	raw := sysRtoInfo{
+		Initial: uint32(initial / time.Millisecond),
+		Max:     uint32(max / time.Millisecond),
+		Min:     uint32(min / time.Millisecond),
+	}
+	return sctpSetsockopt(fd, syscall.SCTP_RTOINFO, unsafe.Pointer(&raw), unsafe.Sizeof(raw))
+}
+
+func setSCTPAssocParams(fd *netFD, p SCTPAssocParams) error {
+	raw := sysAssocParams{
+		AssocMaxRxt:            p.MaxRetrans,
+		NumberPeerDestinations: p.NumberPeerDestinations,
+		PeerRwnd:               p.PeerRwnd,
+		LocalRwnd:              p.LocalRwnd,
+		CookieLife:             uint32(p.CookieLife / time.Millisecond),
+	}
+	return sctpSetsockopt(fd, syscall.SCTP_ASSOCINFO, unsafe.Pointer(&raw), unsafe.Sizeof(raw))
+}
+
+func setSCTPPeerAddrParams(fd *netFD, addr IP, hbInterval time.Duration, pathMaxRxt uint16, enableHB bool) error {
+	family := (&SCTPAddr{IPs: []IP{addr}}).family()
+	sa, err := ipToSockaddr(family, addr, 0, "")
+	if err != nil {
+		return err
+	}
+	b, err := marshalSCTPAddr(sa)
+	if err != nil {
+		return err
+	}
+	flags := uint32(syscall.SPP_HB_DISABLE)
+	if enableHB {
+		flags = syscall.SPP_HB_ENABLE
+	}
+	raw := marshalPeerAddrParams(0, b, uint32(hbInterval/time.Millisecond), pathMaxRxt, flags)
+	return sctpSetsockoptBytes(fd, syscall.SCTP_PEER_ADDR_PARAMS, raw)
+}